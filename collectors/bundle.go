@@ -0,0 +1,78 @@
+package collectors
+
+import (
+	"fmt"
+	"sort"
+
+	"web2epub/collectors/cache"
+)
+
+// Source pairs a start URL with the config used to crawl it, so a Bundle
+// can combine e.g. one conference volume and one scripture volume, each
+// using its own profile.
+type Source struct {
+	URL    string
+	Config *CollectorConfig
+}
+
+// BundleGroup holds everything collected from a single Source: its
+// discovered links, fetched pages, and the title to use for its top-level
+// section in the combined EPUB.
+type BundleGroup struct {
+	Source *Source
+	Title  string
+	Links  []LinkInfo
+	Pages  map[string]*PageContent
+}
+
+// Bundle is an ordered list of BundleGroups, each becoming a top-level
+// section with its crawled pages nested as subsections, so the combined
+// EPUB's NCX/nav reflects real per-source nesting instead of one flat
+// section list.
+type Bundle struct {
+	Groups []*BundleGroup
+}
+
+// CollectBundle discovers links and fetches pages for every source in
+// order, returning a Bundle ready to be laid out by the caller.
+func CollectBundle(sources []Source, sameHostOnly bool, tempDir string, downloadImageFunc func(string, string) (string, error), progress *Progress, pageCache *cache.Cache) (*Bundle, error) {
+	bundle := &Bundle{}
+
+	for i := range sources {
+		source := sources[i]
+
+		links, title, err := CollectLinks(source.URL, source.Config, sameHostOnly, progress, pageCache)
+		if err != nil {
+			return nil, fmt.Errorf("collecting links for %s: %w", source.URL, err)
+		}
+
+		pages, err := CollectPages(links, source.Config, tempDir, downloadImageFunc, progress, pageCache)
+		if err != nil {
+			return nil, fmt.Errorf("collecting pages for %s: %w", source.URL, err)
+		}
+
+		bundle.Groups = append(bundle.Groups, &BundleGroup{
+			Source: &source,
+			Title:  title,
+			Links:  links,
+			Pages:  pages,
+		})
+	}
+
+	return bundle, nil
+}
+
+// SortedPages returns a group's pages ordered by their discovery Order. It
+// builds the slice by appending and sorting rather than indexing by Order
+// directly, since Order has gaps whenever a page failed to fetch or was
+// skipped for having no usable content - both expected outcomes, not bugs.
+func (g *BundleGroup) SortedPages() []*PageContent {
+	sorted := make([]*PageContent, 0, len(g.Pages))
+	for _, page := range g.Pages {
+		sorted = append(sorted, page)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Order < sorted[j].Order
+	})
+	return sorted
+}
@@ -0,0 +1,296 @@
+// Package cache provides an on-disk cache of fetched pages and images,
+// fronted by a bounded in-memory LRU so repeat runs against the same URL
+// (common while iterating on selectors) don't re-hit the network.
+package cache
+
+import (
+	"bufio"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// entry is one cached fetch: the response body plus the headers needed to
+// make a conditional request (ETag / Last-Modified) next time.
+type entry struct {
+	key     string
+	body    []byte
+	headers http.Header
+}
+
+// Cache is an on-disk store of fetched bodies under caller-supplied keys
+// (Transport keys every fetch, images included, by canonicalized request
+// URL), with a bounded in-memory LRU in front of it. Bodies are not
+// deduplicated by content: identical bytes fetched from two URLs are stored
+// twice.
+// The LRU targets roughly 1/4 of system RAM by default, evicting the oldest
+// entries whenever runtime.MemStats reports HeapAlloc past the threshold.
+// All methods are safe for concurrent use.
+type Cache struct {
+	dir       string
+	memLimit  uint64
+	mu        sync.Mutex
+	order     *list.List
+	items     map[string]*list.Element
+	heapAlloc func() uint64
+	stop      chan struct{}
+}
+
+// defaultMemoryFraction is the divisor applied to system RAM (4 means 1/4)
+// to get the in-memory LRU's limit when WEB2EPUB_MEMORYLIMIT isn't set.
+const defaultMemoryFraction = 4
+
+// New creates a Cache rooted at dir (typically
+// $XDG_CACHE_HOME/web2epub). The memory limit defaults to ~1/4 of total
+// system RAM, overridable via the WEB2EPUB_MEMORYLIMIT environment
+// variable (expressed in GB).
+func New(dir string) (*Cache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("creating cache dir %s: %w", dir, err)
+	}
+
+	c := &Cache{
+		dir:      dir,
+		memLimit: memLimitFromEnv(),
+		order:    list.New(),
+		items:    make(map[string]*list.Element),
+		heapAlloc: func() uint64 {
+			var m runtime.MemStats
+			runtime.ReadMemStats(&m)
+			return m.HeapAlloc
+		},
+		stop: make(chan struct{}),
+	}
+
+	go c.evictLoop()
+
+	return c, nil
+}
+
+// DefaultDir returns $XDG_CACHE_HOME/web2epub, falling back to
+// ~/.cache/web2epub when XDG_CACHE_HOME isn't set.
+func DefaultDir() string {
+	if cacheHome := os.Getenv("XDG_CACHE_HOME"); cacheHome != "" {
+		return filepath.Join(cacheHome, "web2epub")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "web2epub-cache")
+	}
+	return filepath.Join(home, ".cache", "web2epub")
+}
+
+// memLimitFromEnv reads WEB2EPUB_MEMORYLIMIT (in GB) or falls back to
+// ~1/4 of total installed system RAM.
+func memLimitFromEnv() uint64 {
+	if raw := os.Getenv("WEB2EPUB_MEMORYLIMIT"); raw != "" {
+		if gb, err := strconv.ParseFloat(raw, 64); err == nil && gb > 0 {
+			return uint64(gb * 1024 * 1024 * 1024)
+		}
+	}
+
+	if total, ok := systemMemory(); ok {
+		return total / defaultMemoryFraction
+	}
+	return 512 * 1024 * 1024
+}
+
+// systemMemory returns total installed RAM in bytes. runtime.MemStats.Sys
+// only reports what this process has obtained from the OS so far (a few MB
+// at startup), not total system RAM, so it can't be used for this.
+func systemMemory() (uint64, bool) {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var label string
+		var kb uint64
+		if _, err := fmt.Sscanf(scanner.Text(), "%s %d", &label, &kb); err != nil {
+			continue
+		}
+		if label == "MemTotal:" {
+			return kb * 1024, true
+		}
+	}
+	return 0, false
+}
+
+// Key canonicalizes a URL (or any other string, such as a content hash) into
+// the cache key used both for the in-memory LRU and the on-disk path.
+func Key(raw string) string {
+	sum := sha256.Sum256([]byte(canonicalizeURL(raw)))
+	return hex.EncodeToString(sum[:])
+}
+
+// canonicalizeURL normalizes raw so otherwise-identical URLs that differ
+// only in scheme/host case, default port, trailing slash, query-param
+// order, or fragment hash to the same key. If raw doesn't parse as a URL,
+// it's returned unchanged.
+func canonicalizeURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+
+	if (u.Scheme == "http" && strings.HasSuffix(u.Host, ":80")) ||
+		(u.Scheme == "https" && strings.HasSuffix(u.Host, ":443")) {
+		u.Host = u.Host[:strings.LastIndex(u.Host, ":")]
+	}
+
+	if u.Path == "" {
+		u.Path = "/"
+	} else if u.Path != "/" {
+		u.Path = strings.TrimSuffix(u.Path, "/")
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery = u.Query().Encode() // Encode() sorts by key
+	}
+
+	u.Fragment = ""
+
+	return u.String()
+}
+
+// path returns the on-disk directory for a key, sharded by the first two
+// hex characters of the hash so no single directory gets too large.
+func (c *Cache) path(key string) string {
+	return filepath.Join(c.dir, key[:2], key)
+}
+
+// Get returns the cached body and headers for key, if present either in
+// memory or on disk.
+func (c *Cache) Get(key string) ([]byte, http.Header, bool) {
+	c.mu.Lock()
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		e := el.Value.(*entry)
+		c.mu.Unlock()
+		return e.body, e.headers, true
+	}
+	c.mu.Unlock()
+
+	body, headers, ok := c.readDisk(key)
+	if !ok {
+		return nil, nil, false
+	}
+
+	c.promote(key, body, headers)
+	return body, headers, true
+}
+
+// Put stores body and headers for key, both on disk and in the in-memory
+// LRU.
+func (c *Cache) Put(key string, body []byte, headers http.Header) error {
+	if err := c.writeDisk(key, body, headers); err != nil {
+		return err
+	}
+	c.promote(key, body, headers)
+	return nil
+}
+
+// Close stops the background eviction loop.
+func (c *Cache) Close() {
+	close(c.stop)
+}
+
+func (c *Cache) promote(key string, body []byte, headers http.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.items[key]; ok {
+		el.Value.(*entry).body = body
+		el.Value.(*entry).headers = headers
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&entry{key: key, body: body, headers: headers})
+	c.items[key] = el
+}
+
+// evictLoop periodically checks HeapAlloc against the configured memory
+// limit and evicts the least-recently-used in-memory entries until it's
+// back under the threshold. It never touches the on-disk copies.
+func (c *Cache) evictLoop() {
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			for c.heapAlloc() > c.memLimit {
+				c.mu.Lock()
+				oldest := c.order.Back()
+				if oldest == nil {
+					c.mu.Unlock()
+					break
+				}
+				e := oldest.Value.(*entry)
+				c.order.Remove(oldest)
+				delete(c.items, e.key)
+				c.mu.Unlock()
+			}
+		}
+	}
+}
+
+// headerFile/bodyFile split storage so the body can be copied/streamed
+// without decoding JSON first.
+func (c *Cache) writeDisk(key string, body []byte, headers http.Header) error {
+	dir := c.path(key)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("creating cache entry dir: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "body"), body, 0644); err != nil {
+		return fmt.Errorf("writing cached body: %w", err)
+	}
+
+	headerBytes, err := json.Marshal(headers)
+	if err != nil {
+		return fmt.Errorf("marshaling cached headers: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "headers.json"), headerBytes, 0644); err != nil {
+		return fmt.Errorf("writing cached headers: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Cache) readDisk(key string) ([]byte, http.Header, bool) {
+	dir := c.path(key)
+
+	body, err := os.ReadFile(filepath.Join(dir, "body"))
+	if err != nil {
+		return nil, nil, false
+	}
+
+	var headers http.Header
+	headerBytes, err := os.ReadFile(filepath.Join(dir, "headers.json"))
+	if err == nil {
+		_ = json.Unmarshal(headerBytes, &headers)
+	}
+
+	return body, headers, true
+}
@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"bufio"
+	"bytes"
+	"net/http"
+)
+
+// Transport wraps an http.RoundTripper with a Cache, keyed by canonicalized
+// request URL. A cached entry's ETag/Last-Modified (if the origin sent them)
+// is replayed as If-None-Match/If-Modified-Since on the next request, so a
+// 304 response costs a round trip but no body transfer; a cache miss, or a
+// fresh 200, is stored for next time. Plug it into colly with
+// c.WithTransport(cache.NewTransport(cache, nil)).
+type Transport struct {
+	Cache *Cache
+	Base  http.RoundTripper
+}
+
+// NewTransport wraps base (http.DefaultTransport if nil) with cache.
+func NewTransport(cache *Cache, base http.RoundTripper) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{Cache: cache, Base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != http.MethodGet {
+		return t.Base.RoundTrip(req)
+	}
+
+	key := Key(req.URL.String())
+
+	cachedBody, cachedHeaders, hit := t.Cache.Get(key)
+	if hit {
+		if etag := cachedHeaders.Get("ETag"); etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		if lastMod := cachedHeaders.Get("Last-Modified"); lastMod != "" {
+			req.Header.Set("If-Modified-Since", lastMod)
+		}
+	}
+
+	resp, err := t.Base.RoundTrip(req)
+	if err != nil {
+		if hit {
+			return cachedResponse(req, cachedBody, cachedHeaders), nil
+		}
+		return nil, err
+	}
+
+	if hit && resp.StatusCode == http.StatusNotModified {
+		resp.Body.Close()
+		return cachedResponse(req, cachedBody, cachedHeaders), nil
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return resp, nil
+	}
+
+	body, err := readAndRestore(resp)
+	if err != nil {
+		return resp, nil
+	}
+
+	_ = t.Cache.Put(key, body, resp.Header)
+
+	return resp, nil
+}
+
+// readAndRestore drains resp.Body and replaces it with a fresh reader over
+// the same bytes, so callers downstream of the transport still see the
+// full response.
+func readAndRestore(resp *http.Response) ([]byte, error) {
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		return nil, err
+	}
+
+	resp.Body = &readCloser{bufio.NewReader(bytes.NewReader(buf.Bytes()))}
+
+	return buf.Bytes(), nil
+}
+
+type readCloser struct {
+	*bufio.Reader
+}
+
+func (readCloser) Close() error { return nil }
+
+// cachedResponse builds a synthetic 200 OK http.Response from a cache
+// entry, so the rest of the stack (colly, goquery) can't tell the
+// difference from a live fetch.
+func cachedResponse(req *http.Request, body []byte, headers http.Header) *http.Response {
+	if headers == nil {
+		headers = http.Header{}
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    http.StatusOK,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        headers,
+		Body:          &readCloser{bufio.NewReader(bytes.NewReader(body))},
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
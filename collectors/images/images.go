@@ -0,0 +1,201 @@
+// Package images consolidates image handling for web2epub: fetching,
+// content-type sniffing, content-hash dedup, and re-encoding to sizes that
+// e-readers handle well.
+package images
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/disintegration/imaging"
+)
+
+// Options controls how a single image is fetched and processed.
+type Options struct {
+	Dir      string       // Output directory
+	MaxWidth int          // Resize down to this width if wider, 0 disables resizing
+	Client   *http.Client // HTTP client used to fetch imageURL, defaults to http.DefaultClient
+}
+
+// ProcessImage fetches imageURL, sniffs its content type, optionally
+// resizes it and converts PNG to JPEG when no alpha channel is present,
+// and writes the result under opts.Dir named by content hash so
+// identical images (even from different URLs) dedupe to one file. It is
+// the single entry point the colly callback and the cover-image path both
+// call.
+func ProcessImage(imageURL string, opts Options) (localPath, mime string, err error) {
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Get(imageURL)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", "", err
+	}
+
+	mime = http.DetectContentType(body)
+
+	body, mime = reencode(body, mime, opts.MaxWidth)
+
+	sum := sha256.Sum256(body)
+	filename := hex.EncodeToString(sum[:]) + extensionForMime(mime)
+	localPath = filepath.Join(opts.Dir, filename)
+
+	if _, err := os.Stat(localPath); err == nil {
+		return localPath, mime, nil
+	}
+
+	if err := os.WriteFile(localPath, body, 0644); err != nil {
+		return "", "", fmt.Errorf("writing processed image: %w", err)
+	}
+
+	return localPath, mime, nil
+}
+
+// reencode resizes body down to maxWidth (if set and narrower than the
+// source) and converts PNG without an alpha channel to JPEG, since that's
+// smaller and universally supported by Kindle/Kobo readers. Any decode
+// failure, or a mime type imaging can't handle, returns body unchanged.
+// GIFs are handled separately by reencodeGIF, since imaging.Decode only
+// returns a GIF's first frame and would silently destroy animation.
+func reencode(body []byte, mime string, maxWidth int) ([]byte, string) {
+	if mime == "image/gif" {
+		return reencodeGIF(body, maxWidth)
+	}
+
+	if mime != "image/png" && mime != "image/jpeg" {
+		return body, mime
+	}
+
+	img, err := imaging.Decode(bytes.NewReader(body), imaging.AutoOrientation(true))
+	if err != nil {
+		return body, mime
+	}
+
+	if maxWidth > 0 && img.Bounds().Dx() > maxWidth {
+		img = imaging.Resize(img, maxWidth, 0, imaging.Lanczos)
+	}
+
+	outMime := mime
+	if mime == "image/png" && !hasAlpha(img) {
+		outMime = "image/jpeg"
+	}
+
+	var buf bytes.Buffer
+	switch outMime {
+	case "image/jpeg":
+		err = jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85})
+	case "image/png":
+		err = png.Encode(&buf, img)
+	default:
+		return body, mime
+	}
+	if err != nil {
+		return body, mime
+	}
+
+	return buf.Bytes(), outMime
+}
+
+// reencodeGIF resizes an animated GIF frame-by-frame with gif.DecodeAll/
+// EncodeAll, preserving its animation. When no resize is needed (disabled,
+// or already narrower than maxWidth) it returns body untouched rather than
+// paying a decode/encode round trip that would collapse it to one frame
+// for nothing.
+func reencodeGIF(body []byte, maxWidth int) ([]byte, string) {
+	if maxWidth <= 0 {
+		return body, "image/gif"
+	}
+
+	cfg, err := gif.DecodeConfig(bytes.NewReader(body))
+	if err != nil || cfg.Width <= maxWidth {
+		return body, "image/gif"
+	}
+
+	g, err := gif.DecodeAll(bytes.NewReader(body))
+	if err != nil {
+		return body, "image/gif"
+	}
+
+	for i, frame := range g.Image {
+		resized := imaging.Resize(frame, maxWidth, 0, imaging.Lanczos)
+		out := image.NewPaletted(resized.Bounds(), frame.Palette)
+		draw.Draw(out, out.Bounds(), resized, resized.Bounds().Min, draw.Src)
+		g.Image[i] = out
+	}
+	g.Config.Width = g.Image[0].Bounds().Dx()
+	g.Config.Height = g.Image[0].Bounds().Dy()
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		return body, "image/gif"
+	}
+
+	return buf.Bytes(), "image/gif"
+}
+
+// hasAlpha reports whether img has any pixel with non-opaque alpha.
+func hasAlpha(img image.Image) bool {
+	switch i := img.(type) {
+	case *image.NRGBA:
+		for p := 3; p < len(i.Pix); p += 4 {
+			if i.Pix[p] != 255 {
+				return true
+			}
+		}
+		return false
+	case *image.RGBA:
+		for p := 3; p < len(i.Pix); p += 4 {
+			if i.Pix[p] != 255 {
+				return true
+			}
+		}
+		return false
+	default:
+		bounds := img.Bounds()
+		for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+			for x := bounds.Min.X; x < bounds.Max.X; x++ {
+				_, _, _, a := img.At(x, y).RGBA()
+				if a != 0xffff {
+					return true
+				}
+			}
+		}
+		return false
+	}
+}
+
+func extensionForMime(mime string) string {
+	switch mime {
+	case "image/jpeg":
+		return ".jpg"
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "image/svg+xml":
+		return ".svg"
+	default:
+		return ".img"
+	}
+}
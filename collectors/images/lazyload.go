@@ -0,0 +1,76 @@
+package images
+
+import (
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// lazyAttrs are checked in order when an <img>'s src is missing or looks
+// like a lazy-load placeholder, covering the common conventions used by
+// modern sites' lazy-loading plugins.
+var lazyAttrs = []string{"data-src", "data-lazy-src", "data-original"}
+
+// ResolveSrc returns the real image URL for s: src when it is set and not a
+// lazy-load placeholder, otherwise the first non-empty lazyAttrs attribute,
+// then the first srcset candidate, then src as it is. Call this before
+// resolving a relative URL against the page, since the real source is
+// often only present in one of these attributes.
+func ResolveSrc(s *goquery.Selection) (string, bool) {
+	if src, exists := s.Attr("src"); exists && !looksLikePlaceholder(src) {
+		return src, true
+	}
+
+	for _, attr := range lazyAttrs {
+		if val, exists := s.Attr(attr); exists && val != "" {
+			return val, true
+		}
+	}
+
+	if srcset, exists := s.Attr("srcset"); exists && srcset != "" {
+		if first := firstSrcsetCandidate(srcset); first != "" {
+			return first, true
+		}
+	}
+
+	if src, exists := s.Attr("src"); exists {
+		return src, true
+	}
+
+	return "", false
+}
+
+// looksLikePlaceholder recognizes the tiny base64 data-URI and blank.gif
+// placeholders lazy-load scripts put in src while the real URL sits in a
+// data-* attribute.
+func looksLikePlaceholder(src string) bool {
+	return src == "" ||
+		len(src) < 100 && hasDataURIPrefix(src) ||
+		strings.HasSuffix(strings.ToLower(src), "blank.gif")
+}
+
+func hasDataURIPrefix(src string) bool {
+	return len(src) >= 5 && src[:5] == "data:"
+}
+
+// firstSrcsetCandidate returns the URL portion of the first candidate in a
+// srcset attribute, e.g. "a.jpg 1x, b.jpg 2x" -> "a.jpg".
+func firstSrcsetCandidate(srcset string) string {
+	for i := 0; i < len(srcset); i++ {
+		if srcset[i] == ',' {
+			srcset = srcset[:i]
+			break
+		}
+	}
+
+	start := 0
+	for start < len(srcset) && srcset[start] == ' ' {
+		start++
+	}
+	end := start
+	for end < len(srcset) && srcset[end] != ' ' {
+		end++
+	}
+
+	return srcset[start:end]
+}
@@ -4,14 +4,18 @@ import (
 	"fmt"
 	"net/url"
 	"path"
+	"regexp"
 	"strings"
+	"time"
+
+	"web2epub/collectors/cache"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 )
 
 // CollectLinks discovers and returns all links from a starting URL using the provided config
-func CollectLinks(startURL string, config *CollectorConfig, sameHostOnly bool) ([]LinkInfo, string, error) {
+func CollectLinks(startURL string, config *CollectorConfig, sameHostOnly bool, progress *Progress, pageCache *cache.Cache) ([]LinkInfo, string, error) {
 	// Parse the starting URL to get the host
 	parsedURL, err := url.Parse(startURL)
 	if err != nil {
@@ -19,14 +23,38 @@ func CollectLinks(startURL string, config *CollectorConfig, sameHostOnly bool) (
 	}
 	hostname := parsedURL.Hostname()
 
+	var includeRegex, excludeRegex *regexp.Regexp
+	if config.IncludeRegex != "" {
+		includeRegex, err = regexp.Compile(config.IncludeRegex)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid include-regex: %w", err)
+		}
+	}
+	if config.ExcludeRegex != "" {
+		excludeRegex, err = regexp.Compile(config.ExcludeRegex)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid exclude-regex: %w", err)
+		}
+	}
+
 	var links []LinkInfo
 	bookTitle := "Default Title"
 	linkOrder := 0
 
-	// Create a collector just for discovering links
+	// Create a collector just for discovering links. MaxDepth(0) means
+	// unlimited depth, which is what lets NextPageSelector follow an
+	// archive's pagination across as many index pages as it has.
 	linkCollector := colly.NewCollector(
 		colly.MaxDepth(0),
 	)
+	if pageCache != nil {
+		linkCollector.WithTransport(cache.NewTransport(pageCache, nil))
+	}
+	linkCollector.Limit(&colly.LimitRule{
+		DomainGlob:  "*",
+		Parallelism: maxInt(config.Parallelism, 1),
+		Delay:       time.Duration(config.DelaySeconds) * time.Second,
+	})
 
 	// Set up the callback for link discovery
 	linkCollector.OnHTML("html", func(e *colly.HTMLElement) {
@@ -79,21 +107,49 @@ func CollectLinks(startURL string, config *CollectorConfig, sameHostOnly bool) (
 				}
 			}
 
-			if !strings.Contains(link, config.LinkFilter) {
-				// Store the link with its order
-				// link = strings.ReplaceAll(link, "/_contents", "")
-				links = append(links, LinkInfo{
-					URL:          link,
-					Order:        linkOrder,
-					IsSubSection: isSubSection,
-				})
-				linkOrder++
+			if config.LinkFilter != "" && strings.Contains(link, config.LinkFilter) {
+				return
+			}
+			if includeRegex != nil && !includeRegex.MatchString(link) {
+				return
+			}
+			if excludeRegex != nil && excludeRegex.MatchString(link) {
+				return
 			}
+
+			// Store the link with its order
+			// link = strings.ReplaceAll(link, "/_contents", "")
+			links = append(links, LinkInfo{
+				URL:          link,
+				Order:        linkOrder,
+				IsSubSection: isSubSection,
+			})
+			linkOrder++
+			progress.LinkDiscovered()
+		})
+
+		// Follow pagination on the index page itself, if configured, so
+		// links accumulate across an archive's many ToC pages
+		if config.NextPageSelector != "" {
+			if nextHref, exists := e.DOM.Find(config.NextPageSelector).Attr("href"); exists {
+				if nextURL := e.Request.AbsoluteURL(nextHref); nextURL != "" {
+					if err := e.Request.Visit(nextURL); err != nil {
+						progress.Defer(func() {
+							Log.Warn().Str("url", nextURL).Str("selector", config.NextPageSelector).Err(err).Msg("failed to follow next-page link")
+						})
+					}
+				}
+			}
+		}
+	})
+
+	linkCollector.OnError(func(r *colly.Response, err error) {
+		progress.Defer(func() {
+			Log.Warn().Str("url", r.Request.URL.String()).Int("status", r.StatusCode).Err(err).Msg("error discovering links")
 		})
 	})
 
 	// Start link discovery
-	fmt.Printf("Discovering links at %s\n", startURL)
 	err = linkCollector.Visit(startURL)
 	if err != nil {
 		return nil, "", fmt.Errorf("failed to visit starting URL: %w", err)
@@ -101,16 +157,47 @@ func CollectLinks(startURL string, config *CollectorConfig, sameHostOnly bool) (
 
 	linkCollector.Wait()
 
-	// Truncate to 10 links for testing
-	var testLinksList []LinkInfo
-	for _, link := range links {
-		if link.Order < 10 {
-			fmt.Printf("%s\n", link.URL)
-			testLinksList = append(testLinksList, link)
-		}
+	links = applyCrawlControls(links, config)
+
+	return links, bookTitle, nil
+}
+
+// applyCrawlControls slices discovered links down to [StartIndex, EndIndex]
+// and MaxPages, re-numbering Order so it stays a contiguous 0-based index
+// into the result.
+func applyCrawlControls(links []LinkInfo, config *CollectorConfig) []LinkInfo {
+	start := config.StartIndex
+	if start < 0 {
+		start = 0
 	}
 
-	links = testLinksList
+	end := len(links)
+	if config.EndIndex > 0 && config.EndIndex+1 < end {
+		end = config.EndIndex + 1
+	}
 
-	return links, bookTitle, nil
+	if start >= end {
+		return nil
+	}
+
+	windowed := links[start:end]
+
+	if config.MaxPages > 0 && len(windowed) > config.MaxPages {
+		windowed = windowed[:config.MaxPages]
+	}
+
+	result := make([]LinkInfo, len(windowed))
+	for i, link := range windowed {
+		link.Order = i
+		result[i] = link
+	}
+
+	return result
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
 }
@@ -0,0 +1,34 @@
+package collectors
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rs/zerolog"
+)
+
+// Log is the package-level structured logger used throughout collectors
+// and main. It defaults to info-level console output; call ConfigureLog
+// once flags are parsed to apply --log-level/--log-format.
+var Log = zerolog.New(zerolog.ConsoleWriter{Out: os.Stderr}).With().Timestamp().Logger().Level(zerolog.InfoLevel)
+
+// ConfigureLog sets Log's level and output format from the --log-level
+// (trace/debug/info/warn/error) and --log-format (console/json) flags.
+func ConfigureLog(level, format string) error {
+	parsedLevel, err := zerolog.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", level, err)
+	}
+
+	var writer io.Writer = os.Stderr
+	if format == "console" {
+		writer = zerolog.ConsoleWriter{Out: os.Stderr}
+	} else if format != "json" {
+		return fmt.Errorf("invalid log format %q, expected console or json", format)
+	}
+
+	Log = zerolog.New(writer).With().Timestamp().Logger().Level(parsedLevel)
+
+	return nil
+}
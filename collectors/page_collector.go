@@ -2,16 +2,20 @@ package collectors
 
 import (
 	"fmt"
-	"log"
+	neturl "net/url"
 	"strings"
 	"time"
 
+	"web2epub/collectors/cache"
+	"web2epub/collectors/images"
+
 	"github.com/PuerkitoBio/goquery"
+	"github.com/go-shiori/go-readability"
 	"github.com/gocolly/colly/v2"
 )
 
 // CollectPages processes the discovered links and extracts content from each page
-func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, downloadImageFunc func(string, string) (string, error)) (map[string]*PageContent, error) {
+func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, downloadImageFunc func(string, string) (string, error), progress *Progress, pageCache *cache.Cache) (map[string]*PageContent, error) {
 	pages := make(map[string]*PageContent)
 
 	// Create collector for page processing
@@ -19,6 +23,9 @@ func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, dow
 		colly.MaxDepth(0), // We already have all links, no need to crawl further
 		colly.Async(true),
 	)
+	if pageCache != nil {
+		pageCollector.WithTransport(cache.NewTransport(pageCache, nil))
+	}
 
 	// Set up parallel processing with config
 	pageCollector.Limit(&colly.LimitRule{
@@ -61,14 +68,21 @@ func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, dow
 		// Extract the main content using configured selector
 		var article *goquery.Selection
 		content := e.DOM.Find(config.ContentSelector)
-		if content.Length() > 0 {
+		if config.ContentSelector != "" && content.Length() > 0 {
 			article = content
 			// Remove unwanted elements from article content
 			for _, selector := range config.RemoveSelectors {
 				article.Find(selector).Remove()
 			}
+		} else if readable := readabilityArticle(pageURL, e.DOM); readable != nil {
+			article = readable
+			for _, selector := range config.RemoveSelectors {
+				article.Find(selector).Remove()
+			}
 		} else if config.FallbackToBody {
-			log.Printf("'%s' element not found for %s, falling back to body content...", config.ContentSelector, pageURL)
+			progress.Defer(func() {
+				Log.Debug().Str("url", pageURL).Str("selector", config.ContentSelector).Msg("content selector not found, falling back to body")
+			})
 			// Fallback to body content with cleaning
 			e.DOM.Find("body").Each(func(i int, s *goquery.Selection) {
 				// Remove unwanted elements
@@ -78,7 +92,9 @@ func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, dow
 				article = s
 			})
 		} else {
-			log.Printf("'%s' element not found for %s and fallback disabled", config.ContentSelector, pageURL)
+			progress.Defer(func() {
+				Log.Warn().Str("url", pageURL).Str("selector", config.ContentSelector).Msg("content selector not found and fallback disabled")
+			})
 			return
 		}
 
@@ -92,11 +108,14 @@ func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, dow
 		// Download images if downloadImageFunc is provided
 		if downloadImageFunc != nil {
 			e.DOM.Find("img").Each(func(i int, s *goquery.Selection) {
-				imgURL, exists := s.Attr("src")
+				imgURL, exists := images.ResolveSrc(s)
 				if exists {
+					imgURL = e.Request.AbsoluteURL(imgURL)
 					outputPath, err := downloadImageFunc(imgURL, tempDir)
 					if err != nil {
-						log.Printf("Error downloading image %s: %v", imgURL, err)
+						progress.Defer(func() {
+							Log.Warn().Str("url", imgURL).Err(err).Msg("failed to download image")
+						})
 					} else {
 						// Create a new img tag with just the src attribute
 						newImg := fmt.Sprintf(`<img src="%s">`, outputPath)
@@ -119,15 +138,24 @@ func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, dow
 
 	// Set up error handling
 	pageCollector.OnError(func(r *colly.Response, err error) {
-		log.Printf("Error visiting %s: %v", r.Request.URL, err)
+		progress.Defer(func() {
+			Log.Warn().Str("url", r.Request.URL.String()).Int("status", r.StatusCode).Err(err).Msg("error visiting page")
+		})
+		progress.LinkFailed()
+	})
+
+	pageCollector.OnScraped(func(r *colly.Response) {
+		progress.LinkFetched()
 	})
 
 	// Process all discovered links
-	fmt.Printf("Processing %d discovered pages\n", len(links))
 	for _, link := range links {
+		link := link
 		err := pageCollector.Visit(link.URL)
 		if err != nil {
-			log.Printf("Error queuing %s: %v", link.URL, err)
+			progress.Defer(func() {
+				Log.Warn().Str("url", link.URL).Int("order", link.Order).Err(err).Msg("failed to queue page")
+			})
 		}
 	}
 
@@ -135,4 +163,36 @@ func CollectPages(links []LinkInfo, config *CollectorConfig, tempDir string, dow
 	pageCollector.Wait()
 
 	return pages, nil
+}
+
+// readabilityArticle runs go-readability over a page's DOM and returns its
+// cleaned content as a goquery selection, or nil if readability couldn't
+// extract anything usable. Used as a fallback when a profile's
+// ContentSelector is empty or matches nothing, so generic sites work
+// without a dedicated profile.
+func readabilityArticle(pageURL string, dom *goquery.Selection) *goquery.Selection {
+	html, err := dom.Parent().Html()
+	if err != nil || html == "" {
+		html, err = dom.Html()
+		if err != nil {
+			return nil
+		}
+	}
+
+	parsedURL, err := neturl.Parse(pageURL)
+	if err != nil {
+		return nil
+	}
+
+	article, err := readability.FromReader(strings.NewReader(html), parsedURL)
+	if err != nil || article.Content == "" {
+		return nil
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(article.Content))
+	if err != nil {
+		return nil
+	}
+
+	return doc.Selection
 }
\ No newline at end of file
@@ -0,0 +1,102 @@
+package collectors
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileSearchPaths returns the ordered list of directories searched for a
+// named profile file, builtin configs always taking precedence over
+// user-supplied ones with the same name.
+func ProfileSearchPaths() []string {
+	var paths []string
+
+	if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "web2epub", "profiles"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		paths = append(paths, filepath.Join(home, ".config", "web2epub", "profiles"))
+	}
+
+	return paths
+}
+
+// LoadProfile loads a CollectorConfig from a YAML or TOML file at path. The
+// file format is inferred from its extension (.yaml/.yml or .toml).
+func LoadProfile(path string) (*CollectorConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading profile %s: %w", path, err)
+	}
+
+	config := &CollectorConfig{}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parsing YAML profile %s: %w", path, err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, config); err != nil {
+			return nil, fmt.Errorf("parsing TOML profile %s: %w", path, err)
+		}
+	default:
+		return nil, fmt.Errorf("unrecognized profile format %s (expected .yaml, .yml or .toml)", path)
+	}
+
+	if config.CollectorType == "" {
+		config.CollectorType = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+
+	if config.LinkSelector == "" {
+		return nil, fmt.Errorf("profile %s: link_selector is empty after parsing - check its keys match CollectorConfig's yaml/toml tags (e.g. link_selector, not LinkSelector)", path)
+	}
+
+	return config, nil
+}
+
+// FindProfile searches ProfileSearchPaths for a profile named name (with
+// either a .yaml or .toml extension) and loads it.
+func FindProfile(name string) (*CollectorConfig, error) {
+	for _, dir := range ProfileSearchPaths() {
+		for _, ext := range []string{".yaml", ".yml", ".toml"} {
+			candidate := filepath.Join(dir, name+ext)
+			if _, err := os.Stat(candidate); err == nil {
+				return LoadProfile(candidate)
+			}
+		}
+	}
+
+	return nil, fmt.Errorf("no profile named %q found in %v", name, ProfileSearchPaths())
+}
+
+// GetReadabilityConfig returns a generic config for arbitrary blogs and news
+// sites. It leaves ContentSelector empty so CollectPages falls back to
+// go-readability instead of relying on a site-specific selector.
+func GetReadabilityConfig() *CollectorConfig {
+	return &CollectorConfig{
+		CollectorType:   "readability",
+		LinkSelector:    "a[href]",
+		TitleSelector:   "title",
+		AuthorSelector:  "",
+		ContentSelector: "",
+		RemoveSelectors: []string{
+			"script", "footer", "iframe", "button",
+			".nav", ".menu", ".sidebar", ".ad", ".ads",
+		},
+		AuthorReplacements:  map[string]string{},
+		DefaultAuthor:       "Unknown",
+		SubSectionThreshold: 100,
+		FallbackToBody:      true,
+		Parallelism:         4,
+		DelaySeconds:        2,
+		SkipExtensions: []string{
+			".jpg", ".jpeg", ".png", ".gif",
+			".pdf", ".zip", ".mp3", ".mp4",
+		},
+	}
+}
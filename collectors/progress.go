@@ -0,0 +1,168 @@
+package collectors
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/vbauerster/mpb/v8"
+	"github.com/vbauerster/mpb/v8/decor"
+)
+
+// Progress tracks the three bars shown while a book is built: links
+// discovered vs. fetched, images downloaded, and sections added to the
+// EPUB. All increment methods are safe to call from colly's concurrent
+// OnHTML/OnError/OnScraped callbacks. When quiet is set, every bar method is
+// a no-op so cron/CI runs stay free of progress output; Defer then runs its
+// log call immediately.
+type Progress struct {
+	quiet bool
+	prog  *mpb.Progress
+
+	mu            sync.Mutex
+	linksBar      *mpb.Bar
+	linksTotal    int64
+	linksFetched  int64
+	linksFailed   int64
+	imagesBar     *mpb.Bar
+	imagesFetched int64
+	sectionsBar   *mpb.Bar
+	sectionsAdded int64
+	deferredLogs  []func()
+}
+
+// NewProgress creates the three mpb bars, or a quiet Progress whose methods
+// do nothing when quiet is true.
+func NewProgress(quiet bool) *Progress {
+	if quiet {
+		return &Progress{quiet: true}
+	}
+
+	p := &Progress{prog: mpb.New(mpb.WithWidth(64))}
+
+	failedDecor := decor.Any(func(s decor.Statistics) string {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.linksFailed == 0 {
+			return ""
+		}
+		return fmt.Sprintf("\033[31mfailed: %d\033[0m", p.linksFailed)
+	})
+
+	p.linksBar = p.prog.AddBar(0,
+		mpb.PrependDecorators(decor.Name("links   ", decor.WC{W: 10})),
+		mpb.AppendDecorators(decor.CountersNoUnit("%d / %d"), decor.Name(" "), failedDecor),
+	)
+	p.imagesBar = p.prog.AddBar(0,
+		mpb.PrependDecorators(decor.Name("images  ", decor.WC{W: 10})),
+		mpb.AppendDecorators(decor.CountersNoUnit("%d / %d")),
+	)
+	p.sectionsBar = p.prog.AddBar(0,
+		mpb.PrependDecorators(decor.Name("sections", decor.WC{W: 10})),
+		mpb.AppendDecorators(decor.CountersNoUnit("%d / %d")),
+	)
+
+	return p
+}
+
+// LinkDiscovered records a newly discovered link, growing the links bar's
+// total so it tracks discovered-vs-fetched instead of a fixed count.
+func (p *Progress) LinkDiscovered() {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	p.linksTotal++
+	total := p.linksTotal
+	p.mu.Unlock()
+	p.linksBar.SetTotal(total, false)
+}
+
+// LinkFetched records a successfully fetched page.
+func (p *Progress) LinkFetched() {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	p.linksFetched++
+	p.mu.Unlock()
+	p.linksBar.Increment()
+}
+
+// LinkFailed records a page that errored out while being fetched. It still
+// advances the bar (the link is no longer pending) but surfaces the failure
+// via the red failed-count decorator.
+func (p *Progress) LinkFailed() {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	p.linksFailed++
+	p.mu.Unlock()
+	p.linksBar.Increment()
+}
+
+// ImageDownloaded records one image fetch, successful or not, against the
+// images bar's total.
+func (p *Progress) ImageDownloaded() {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	p.imagesFetched++
+	total := p.imagesFetched
+	p.mu.Unlock()
+	p.imagesBar.SetTotal(total, false)
+	p.imagesBar.Increment()
+}
+
+// SectionAdded records one EPUB section or subsection having been added.
+func (p *Progress) SectionAdded(total int) {
+	if p.quiet {
+		return
+	}
+	p.mu.Lock()
+	p.sectionsAdded++
+	p.mu.Unlock()
+	p.sectionsBar.SetTotal(int64(total), false)
+	p.sectionsBar.Increment()
+}
+
+// Defer records a log call to run once the bars are done rendering, instead
+// of calling it now. mpb repaints its bars in place with cursor control, so
+// a Log.Warn() written to stderr mid-render garbles the display; buffering
+// until Wait() keeps log output readable. In --quiet runs (no bars to
+// garble) logFn runs immediately.
+func (p *Progress) Defer(logFn func()) {
+	if p.quiet {
+		logFn()
+		return
+	}
+	p.mu.Lock()
+	p.deferredLogs = append(p.deferredLogs, logFn)
+	p.mu.Unlock()
+}
+
+// Wait blocks until all bars have finished rendering, then flushes any log
+// calls buffered via Defer while they were active. Call it once no more
+// increments will happen, before printing any final summary.
+func (p *Progress) Wait() {
+	if p.quiet || p.prog == nil {
+		return
+	}
+	// The bars were created with total 0 and grow via SetTotal, so none of
+	// them completes on its own; mark each complete at its current count so
+	// mpb's Wait can return.
+	p.linksBar.SetTotal(-1, true)
+	p.imagesBar.SetTotal(-1, true)
+	p.sectionsBar.SetTotal(-1, true)
+	p.prog.Wait()
+
+	p.mu.Lock()
+	deferred := p.deferredLogs
+	p.deferredLogs = nil
+	p.mu.Unlock()
+
+	for _, logFn := range deferred {
+		logFn()
+	}
+}
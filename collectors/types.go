@@ -25,39 +25,55 @@ type LinkInfo struct {
 }
 
 type StringPair struct {
-	OldText      string
-	NewText      string
-	IsSubSection bool
+	OldText      string `yaml:"old_text" toml:"old_text"`
+	NewText      string `yaml:"new_text" toml:"new_text"`
+	IsSubSection bool   `yaml:"is_sub_section" toml:"is_sub_section"`
 }
 
-// CollectorConfig holds configuration for different collection strategies
+// CollectorConfig holds configuration for different collection strategies.
+// Struct tags are snake_case and consistent across YAML and TOML so a
+// profile file written either way parses the same; LoadProfile validates
+// that parsing actually populated the config.
 type CollectorConfig struct {
-	CollectorType string
+	CollectorType string `yaml:"collector_type" toml:"collector_type"`
 
 	// Link discovery settings
-	LinkSelector string       // CSS selector for finding links
-	LinkFilter   string       // String to match in a url
-	LinkReplace  []StringPair // Strings that define a part of a url to replace
+	LinkSelector string       `yaml:"link_selector" toml:"link_selector"` // CSS selector for finding links
+	LinkFilter   string       `yaml:"link_filter" toml:"link_filter"`     // String to match in a url
+	LinkReplace  []StringPair `yaml:"link_replace" toml:"link_replace"`   // Strings that define a part of a url to replace
 
-	TitleSelector   string   // CSS selector for page title
-	AuthorSelector  string   // CSS selector for author
-	ContentSelector string   // CSS selector for main content
-	RemoveSelectors []string // CSS selectors for elements to remove
-	UnwrapSelectors []string // CSS selectors for elements to unwrap (e.g. keep text but remove html tags around the text)
+	TitleSelector   string   `yaml:"title_selector" toml:"title_selector"`     // CSS selector for page title
+	AuthorSelector  string   `yaml:"author_selector" toml:"author_selector"`   // CSS selector for author
+	ContentSelector string   `yaml:"content_selector" toml:"content_selector"` // CSS selector for main content
+	RemoveSelectors []string `yaml:"remove_selectors" toml:"remove_selectors"` // CSS selectors for elements to remove
+	UnwrapSelectors []string `yaml:"unwrap_selectors" toml:"unwrap_selectors"` // CSS selectors for elements to unwrap (e.g. keep text but remove html tags around the text)
 
 	// Author processing
-	AuthorReplacements map[string]string // String replacements for author names
-	DefaultAuthor      string            // Default author if none found
+	AuthorReplacements map[string]string `yaml:"author_replacements" toml:"author_replacements"` // String replacements for author names
+	DefaultAuthor      string            `yaml:"default_author" toml:"default_author"`           // Default author if none found
 
 	// Content processing
-	SubSectionThreshold int  // Content length threshold for subsections
-	FallbackToBody      bool // Fall back to body if content selector fails
+	SubSectionThreshold int  `yaml:"sub_section_threshold" toml:"sub_section_threshold"` // Content length threshold for subsections
+	FallbackToBody      bool `yaml:"fallback_to_body" toml:"fallback_to_body"`           // Fall back to body if content selector fails
 
 	// Crawling settings
-	Parallelism    int      // Number of parallel requests
-	DelaySeconds   int      // Delay between requests
-	SkipExtensions []string // File extensions to skip
-	CollectorCSS   string   // The css to include for the collector type
+	Parallelism    int      `yaml:"parallelism" toml:"parallelism"`         // Number of parallel requests
+	DelaySeconds   int      `yaml:"delay_seconds" toml:"delay_seconds"`     // Delay between requests
+	SkipExtensions []string `yaml:"skip_extensions" toml:"skip_extensions"` // File extensions to skip
+	CollectorCSS   string   `yaml:"collector_css" toml:"collector_css"`     // The css to include for the collector type
+
+	// Crawl controls applied to discovered links, after normalization
+	MaxPages     int    `yaml:"max_pages" toml:"max_pages"`         // Maximum number of links to keep, 0 means unlimited
+	StartIndex   int    `yaml:"start_index" toml:"start_index"`     // First link index to keep (0-based, inclusive)
+	EndIndex     int    `yaml:"end_index" toml:"end_index"`         // Last link index to keep (0-based, inclusive), 0 means unlimited
+	IncludeRegex string `yaml:"include_regex" toml:"include_regex"` // Only keep links matching this regex, if set
+	ExcludeRegex string `yaml:"exclude_regex" toml:"exclude_regex"` // Drop links matching this regex, if set
+
+	// NextPageSelector is a CSS selector for a "next page" link on the
+	// start page (or any page reached via it). When set, CollectLinks
+	// follows it iteratively, accumulating links across an archive/blog's
+	// many index pages, respecting Parallelism/DelaySeconds between hops.
+	NextPageSelector string `yaml:"next_page_selector" toml:"next_page_selector"`
 }
 
 // GetGeneralConferenceConfig returns config for LDS General Conference pages
@@ -187,7 +203,9 @@ func GetEnsignConfig() *CollectorConfig {
 	}
 }
 
-// GetConfigByModule returns the appropriate collector config based on module name
+// GetConfigByModule returns the appropriate collector config based on module
+// name. Builtin modules are checked first; anything else is looked up as a
+// named profile via FindProfile before giving up.
 func GetConfigByModule(module string) (*CollectorConfig, error) {
 	switch strings.ToLower(module) {
 	case "conference", "general-conference":
@@ -196,7 +214,12 @@ func GetConfigByModule(module string) (*CollectorConfig, error) {
 		return GetScripturesConfig(), nil
 	case "ensign":
 		return GetEnsignConfig(), nil
+	case "readability":
+		return GetReadabilityConfig(), nil
 	default:
-		return nil, fmt.Errorf("unknown module: %s. Available modules: conference, scriptures, ensign", module)
+		if config, err := FindProfile(module); err == nil {
+			return config, nil
+		}
+		return nil, fmt.Errorf("unknown module: %s. Available modules: conference, scriptures, ensign, readability, or a profile name in %v", module, ProfileSearchPaths())
 	}
 }
@@ -1,13 +1,8 @@
 package main
 
-//TODO: Look at different logging packages - logrus, zap, zerolog
-
 import (
-	"crypto/rand"
 	"flag"
 	"fmt"
-	"io"
-	"log"
 	"net/http"
 	"os"
 	"path"
@@ -15,6 +10,8 @@ import (
 	"time"
 
 	"web2epub/collectors"
+	"web2epub/collectors/cache"
+	"web2epub/collectors/images"
 
 	"github.com/PuerkitoBio/goquery"
 	"github.com/go-shiori/go-epub"
@@ -22,96 +19,183 @@ import (
 
 func main() {
 	// Define command line flags
-	startURL := flag.String("url", "", "Starting URL to crawl (required)")
+	var startURLs urlList
+	flag.Var(&startURLs, "url", "Starting URL to crawl (required, repeatable to bundle multiple sources into one EPUB; accepts url or url::module)")
+	urlsFile := flag.String("urls-file", "", "File with one starting URL per line, appended to -url")
 	outputFile := flag.String("output", "", "Will grab title from title of first page unless this flag is specified")
 	coverImg := flag.String("cover", "", "URL of desired cover image. Defaults to no cover image")
-	module := flag.String("module", "conference", "Collection module to use (conference, scriptures, ensign)")
+	module := flag.String("module", "conference", "Default collection module to use (conference, scriptures, ensign, readability, or a named profile)")
+	profilePath := flag.String("profile", "", "Path to a YAML/TOML profile file, overriding -module as the default for sources without their own ::module suffix")
 	// Does not support user definable maxDepth at this time
 	//maxDepth := flag.Int("depth", 1, "Maximum crawl depth")
 	sameHostOnly := flag.Bool("same-host", true, "Only crawl pages on the same host")
+	quiet := flag.Bool("quiet", false, "Disable progress bars and rely on plain log output (for cron/CI usage)")
+	maxPages := flag.Int("max-pages", 0, "Maximum number of discovered links to keep, 0 means unlimited")
+	startIndex := flag.Int("start-index", 0, "First discovered link index to keep (0-based, inclusive)")
+	endIndex := flag.Int("end-index", 0, "Last discovered link index to keep (0-based, inclusive), 0 means unlimited")
+	includeRegex := flag.String("include-regex", "", "Only keep discovered links matching this regex")
+	excludeRegex := flag.String("exclude-regex", "", "Drop discovered links matching this regex")
+	maxImageWidth := flag.Int("max-image-width", 1200, "Resize images wider than this down to it (e.g. for Kindle/Kobo), 0 disables resizing")
+	logLevel := flag.String("log-level", "info", "Log level: trace, debug, info, warn, error")
+	logFormat := flag.String("log-format", "console", "Log format: console, json")
 	flag.Parse()
 
+	if err := collectors.ConfigureLog(*logLevel, *logFormat); err != nil {
+		collectors.Log.Fatal().Err(err).Msg("invalid logging flags")
+	}
+
+	if *urlsFile != "" {
+		fromFile, err := readURLsFile(*urlsFile)
+		if err != nil {
+			collectors.Log.Fatal().Err(err).Str("path", *urlsFile).Msg("failed to read urls-file")
+		}
+		startURLs = append(startURLs, fromFile...)
+	}
+
 	// Validate required flags
-	if *startURL == "" {
-		fmt.Println("Error: Starting URL is required")
+	if len(startURLs) == 0 {
+		fmt.Println("Error: At least one -url is required")
 		flag.PrintDefaults()
 		os.Exit(1)
 	}
 
-	// Get the collector configuration for the specified module
-	config, err := collectors.GetConfigByModule(*module)
-	if err != nil {
-		log.Fatal("Module configuration error:", err)
+	// Resolve the default config used by sources that don't specify their
+	// own ::module suffix
+	var defaultConfig *collectors.CollectorConfig
+	var err error
+	if *profilePath != "" {
+		defaultConfig, err = collectors.LoadProfile(*profilePath)
+		if err != nil {
+			collectors.Log.Fatal().Err(err).Str("profile", *profilePath).Msg("profile configuration error")
+		}
+	} else {
+		defaultConfig, err = collectors.GetConfigByModule(*module)
+		if err != nil {
+			collectors.Log.Fatal().Err(err).Str("module", *module).Msg("module configuration error")
+		}
 	}
 
-	// Store the collected pages
-	pages := make(map[string]*collectors.PageContent)
+	sources := make([]collectors.Source, len(startURLs))
+	for i, raw := range startURLs {
+		url, moduleOverride := splitURLModule(raw)
+		resolved := defaultConfig
+		if moduleOverride != "" {
+			resolved, err = collectors.GetConfigByModule(moduleOverride)
+			if err != nil {
+				collectors.Log.Fatal().Err(err).Str("url", url).Str("module", moduleOverride).Msg("module configuration error")
+			}
+		}
+
+		// Copy before applying crawl controls so sources sharing the same
+		// underlying profile don't clobber each other's limits
+		config := *resolved
+		config.MaxPages = *maxPages
+		config.StartIndex = *startIndex
+		config.EndIndex = *endIndex
+		config.IncludeRegex = *includeRegex
+		config.ExcludeRegex = *excludeRegex
+
+		sources[i] = collectors.Source{URL: url, Config: &config}
+	}
 
 	// Create a temporary directory for resource files
 	tempDir, err := os.MkdirTemp("", "epub-builder")
 	if err != nil {
-		log.Fatal("Failed to create temp directory:", err)
+		collectors.Log.Fatal().Err(err).Msg("failed to create temp directory")
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Use the modular collectors to discover links
-	links, bookTitle, err := collectors.CollectLinks(*startURL, config, *sameHostOnly)
+	progress := collectors.NewProgress(*quiet)
+
+	pageCache, err := cache.New(cache.DefaultDir())
 	if err != nil {
-		log.Fatal("Link collection failed:", err)
+		collectors.Log.Fatal().Err(err).Msg("failed to open cache")
 	}
+	defer pageCache.Close()
 
-	// Use custom book title if provided
-	if *outputFile != "" {
-		bookTitle = *outputFile
+	imageClient := &http.Client{Transport: cache.NewTransport(pageCache, nil)}
+	download := func(imageURL, dir string) (string, error) {
+		return downloadImage(imageURL, dir, progress, imageClient, *maxImageWidth)
 	}
 
-	// fmt.Printf("Book Title: %s\n", bookTitle)
-	// fmt.Printf("Number of links found: %d\n", len(links))
-	// for i, link := range links {
-	// 	fmt.Printf(" %d. %s (Order: %d)\n", i+1, link.URL, link.Order)
-	// }
-
-	// os.Exit(0)
-
-	// Use the modular collectors to process pages
-	pages, err = collectors.CollectPages(links, config, tempDir, downloadImage)
+	// Use the modular collectors to discover links and fetch pages for
+	// every source, in order
+	bundle, err := collectors.CollectBundle(sources, *sameHostOnly, tempDir, download, progress, pageCache)
 	if err != nil {
-		log.Fatal("Page collection failed:", err)
+		collectors.Log.Fatal().Err(err).Msg("bundle collection failed")
 	}
 
-	// Write CSS to a file in the temp directory
-	cssPath := path.Join(tempDir, "styles.css")
-	err = os.WriteFile(cssPath, []byte(config.CollectorCSS), 0644)
-	if err != nil {
-		log.Fatal("Error writing CSS file:", err)
+	bookTitle := bundle.Groups[0].Title
+	if *outputFile != "" {
+		bookTitle = *outputFile
 	}
 
 	// Create the EPUB book
 	book, err := epub.NewEpub(bookTitle)
 	if err != nil {
-		log.Fatal("Error creating EPUB:", err)
+		collectors.Log.Fatal().Err(err).Msg("error creating EPUB")
 	}
 	book.SetTitle(bookTitle)
 	book.SetAuthor("Church of Jesus Christ of Latter-day Saints")
-	book.SetDescription(fmt.Sprintf("Content crawled from %s on %s by casrk/web2epub", *startURL, time.Now().Format("2006-01-02")))
-	cssPath, err = book.AddCSS(cssPath, "")
+	book.SetDescription(fmt.Sprintf("Content crawled from %s on %s by casrk/web2epub", strings.Join(startURLs, ", "), time.Now().Format("2006-01-02")))
+
+	totalPages := 0
+	for _, group := range bundle.Groups {
+		totalPages += len(group.Pages)
+	}
+
+	// Add each source as a top-level section, its pages nested as
+	// subsections so the NCX/nav reflects real per-source structure
+	for i, group := range bundle.Groups {
+		cssPath := path.Join(tempDir, fmt.Sprintf("styles-%d.css", i))
+		if err := os.WriteFile(cssPath, []byte(group.Source.Config.CollectorCSS), 0644); err != nil {
+			collectors.Log.Fatal().Err(err).Str("path", cssPath).Msg("error writing CSS file")
+		}
+		cssPath, err = book.AddCSS(cssPath, "")
+		if err != nil {
+			collectors.Log.Fatal().Err(err).Msg("error adding CSS")
+		}
+
+		addGroupToBook(book, group, cssPath, progress, totalPages)
+	}
+
+	//Add cover image
+	if *coverImg != "" {
+		output_path, err := downloadImage(*coverImg, tempDir, progress, imageClient, *maxImageWidth)
+		if err != nil {
+			collectors.Log.Warn().Str("url", *coverImg).Err(err).Msg("error downloading cover image")
+		}
+		ebook_path, err := book.AddImage(output_path, "")
+		if err != nil {
+			collectors.Log.Fatal().Err(err).Msg("error processing cover image")
+		}
+		book.SetCover(ebook_path, "")
+	}
+
+	// Save the EPUB file
+	err = book.Write(bookTitle + ".epub")
 	if err != nil {
-		log.Fatal("Error adding CSS:", err)
+		collectors.Log.Fatal().Err(err).Msg("error writing EPUB")
 	}
 
-	// Sort pages by order
-	sortedPages := make([]*collectors.PageContent, len(pages))
-	for _, page := range pages {
-		// fmt.Printf("Current url: %s\n", page.URL)
-		// fmt.Printf("Page number:%d\n", page.Order)
-		sortedPages[page.Order] = page
+	progress.Wait()
+
+	collectors.Log.Info().Str("title", bookTitle).Int("pages", totalPages).Msg("successfully created EPUB")
+}
+
+// addGroupToBook adds one bundle group's top-level section and nests its
+// pages as subsections beneath it, preserving each page's own
+// section/subsection split from its source config one level deeper.
+func addGroupToBook(book *epub.Epub, group *collectors.BundleGroup, cssPath string, progress *collectors.Progress, totalPages int) {
+	topLink, err := book.AddSection(fmt.Sprintf("<h1>%s</h1>", group.Title), group.Title, "", cssPath)
+	if err != nil {
+		collectors.Log.Warn().Str("url", group.Source.URL).Err(err).Msg("error adding top-level section")
+		return
 	}
 
-	SectionLink := ""
+	sectionLink := topLink
 
-	// Add each page to the EPUB
-	for _, page := range sortedPages {
-		// Create a section in the EPUB
+	for _, page := range group.SortedPages() {
 		var contentBuilder strings.Builder
 
 		// Find all of the img tags in the article
@@ -120,7 +204,7 @@ func main() {
 			if exists {
 				ebook_path, err := book.AddImage(tmp_path, "")
 				if err != nil {
-					log.Fatal("Error processing image:", err)
+					collectors.Log.Fatal().Err(err).Str("path", tmp_path).Msg("error processing image")
 				}
 				// Create a new img tag with just the src attribute
 				newImg := fmt.Sprintf(`<img src="%s">`, ebook_path)
@@ -141,75 +225,78 @@ func main() {
 		title := fmt.Sprintf("%s - %s", page.Title, page.Author)
 
 		if page.IsSubSection {
-			// log.Printf("Subsection")
-			_, err := book.AddSubSection(SectionLink, contentBuilder.String(), title, "", cssPath)
+			_, err := book.AddSubSection(sectionLink, contentBuilder.String(), title, "", cssPath)
 			if err != nil {
-				log.Printf("Error adding subsection for %s: %v", page.URL, err)
+				collectors.Log.Warn().Str("url", page.URL).Err(err).Msg("error adding subsection")
 			}
 		} else {
-			// log.Printf("Section")
-			relativePath, err := book.AddSection(contentBuilder.String(), title, "", cssPath)
+			relativePath, err := book.AddSubSection(topLink, contentBuilder.String(), title, "", cssPath)
 			if err != nil {
-				log.Printf("Error adding section for %s: %v", page.URL, err)
+				collectors.Log.Warn().Str("url", page.URL).Err(err).Msg("error adding subsection")
 			}
-			SectionLink = relativePath
+			sectionLink = relativePath
 		}
 
-		fmt.Printf("Added page: %s\n", title)
+		progress.SectionAdded(totalPages)
 	}
+}
 
-	//Add cover image
-	if *coverImg != "" {
-		output_path, err := downloadImage(*coverImg, tempDir)
-		if err != nil {
-			log.Printf("Error downloading cover image %s: %v", *coverImg, err)
-		}
-		ebook_path, err := book.AddImage(output_path, "")
-		if err != nil {
-			log.Fatal("Error processing cover image:", err)
-		}
-		book.SetCover(ebook_path, "")
-	}
+// urlList collects repeated -url flags into a slice.
+type urlList []string
 
-	// Save the EPUB file
-	err = book.Write(bookTitle + ".epub")
-	if err != nil {
-		log.Fatal("Error writing EPUB:", err)
-	}
+func (u *urlList) String() string {
+	return strings.Join(*u, ",")
+}
 
-	fmt.Printf("\nSuccessfully created EPUB: %s\n", bookTitle)
-	fmt.Printf("Total pages: %d\n", len(pages))
+func (u *urlList) Set(value string) error {
+	*u = append(*u, value)
+	return nil
 }
 
-// downloadImage downloads an image from a URL to the specified directory
-func downloadImage(imageURL, dir string) (string, error) {
-	resp, err := http.Get(imageURL)
-	if err != nil {
-		return "", err
+// splitURLModule splits a "url::module" source spec into its URL and
+// optional per-source module override.
+func splitURLModule(raw string) (url, module string) {
+	if idx := strings.Index(raw, "::"); idx != -1 {
+		return raw[:idx], raw[idx+2:]
 	}
-	defer resp.Body.Close()
-
-	// Create a file with a random name
-	// Generate 16 random bytes
-	b := make([]byte, 16)
-	rand.Read(b)
-	// Convert to hex string and add .jpg extension
-	filename := fmt.Sprintf("%x", b)
-
-	// log.Printf("Filename: %s", filename)
+	return raw, ""
+}
 
-	filepath := path.Join(dir, filename)
-	file, err := os.Create(filepath)
+// readURLsFile reads one source spec per line (blank lines and #-comments
+// ignored) to supplement -url for large bundles.
+func readURLsFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer file.Close()
 
-	// Copy the image data to the file
-	_, err = io.Copy(file, resp.Body)
+	var urls []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		urls = append(urls, line)
+	}
+
+	return urls, nil
+}
+
+// downloadImage fetches, re-encodes and dedupes an image via
+// collectors/images, recording the fetch on the images progress bar. client
+// routes the request through the on-disk cache so repeat runs against the
+// same URL don't re-hit the network.
+func downloadImage(imageURL, dir string, progress *collectors.Progress, client *http.Client, maxImageWidth int) (string, error) {
+	defer progress.ImageDownloaded()
+
+	localPath, _, err := images.ProcessImage(imageURL, images.Options{
+		Dir:      dir,
+		MaxWidth: maxImageWidth,
+		Client:   client,
+	})
 	if err != nil {
 		return "", err
 	}
 
-	return filepath, nil
+	return localPath, nil
 }